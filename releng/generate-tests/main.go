@@ -1,9 +1,7 @@
 package main
 
 import (
-	"bytes"
 	"crypto/sha1"
-	"embed"
 	"encoding/hex"
 	"errors"
 	"flag"
@@ -14,35 +12,49 @@ import (
 	"slices"
 	"strconv"
 	"strings"
-	"text/template"
+	"time"
 
 	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/test-infra/releng/generate-tests/validate"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+	kyaml "sigs.k8s.io/yaml"
 )
 
-//go:embed test.template.yml
-var testTemplateFS embed.FS
-
 const (
 	GCSLOGPREFIX = "kubernetes-jenkins/logs/"
 	COMMENT      = "AUTO-GENERATED by releng/generate-tests/main.go - DO NOT EDIT."
+	// DefaultRepo is the repo that every generated Presubmit/Postsubmit is
+	// grouped under; there is no per-job repo setting.
+	DefaultRepo = "kubernetes/kubernetes"
 )
 
 type options struct {
 	yamlConfigPath     string
 	testgridOutputPath string
 	outputDir          string
+	validateOnly       bool
+	validateWithProw   bool
 }
 
 func parseFlags() *options {
 	opt := options{}
 	flag.StringVar(&opt.outputDir, "output-dir", "config/jobs/kubernetes/generated/", "Write configmap here instead of stdout")
-	flag.StringVar(&opt.testgridOutputPath, "testgrid-output-path", "config/testgrids/generated-test-config.yaml", "Name of resource")
+	flag.StringVar(&opt.testgridOutputPath, "testgrid-output-path", "config/testgrids/generated-test-config.yaml", "Path to write the generated testgrid config to. Testgrid emission is skipped if empty")
 	flag.StringVar(&opt.yamlConfigPath, "yaml-config-path", "", "Namespace for resource")
+	flag.BoolVar(&opt.validateOnly, "validate-only", false, "Only validate the yaml config and exit non-zero on any violation, without generating output")
+	flag.BoolVar(&opt.validateOnly, "dry-run", false, "Alias for --validate-only")
+	flag.BoolVar(&opt.validateWithProw, "validate-with-prow", false, "Run the generated Prow job config through prow/config.Load after writing it")
 	flag.Parse()
 	return &opt
 }
 
-func (opt *options) getYamlConfig() ConfigFile {
+// getYamlConfig reads and parses the yaml config, returning both the
+// decoded ConfigFile and the raw bytes so callers can re-decode into a
+// yaml.Node tree for line-accurate validation.
+func (opt *options) getYamlConfig() (ConfigFile, []byte) {
 	yamlFile, err := os.ReadFile(opt.yamlConfigPath)
 	if err != nil {
 		log.Fatalln("error trying to read yaml config path file")
@@ -52,16 +64,13 @@ func (opt *options) getYamlConfig() ConfigFile {
 	if err != nil {
 		log.Fatalln("error trying to parse yaml config path file")
 	}
-	return config
+	return config, yamlFile
 }
 
 func (opt *options) validateOptions() error {
 	if opt.outputDir == "" {
 		return errors.New("--output-dir must be specified")
 	}
-	if opt.testgridOutputPath == "" {
-		return errors.New("--testgrid-output-path must be specified")
-	}
 	if opt.yamlConfigPath == "" {
 		return errors.New("--yaml-config-path must be specified")
 	}
@@ -73,29 +82,252 @@ func main() {
 	if err := options.validateOptions(); err != nil {
 		log.Fatalln(err)
 	}
-	config := options.getYamlConfig()
+	config, rawConfig := options.getYamlConfig()
+	// mergeGeneratedJobs must run before validateConfig: matrix/upgradeMatrix
+	// entries expand into config.Jobs, and those generated job names need
+	// the same name/arg validation as hand-authored ones.
+	mergeGeneratedJobs(&config)
+	if errs := validateConfig(options.yamlConfigPath, rawConfig, config); len(errs) != 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		log.Fatalf("%d validation error(s) found in %s", len(errs), options.yamlConfigPath)
+	}
+	if options.validateOnly {
+		return
+	}
 	var jobNames []string
 	for name := range config.Jobs {
 		jobNames = append(jobNames, name)
 	}
 	slices.Sort(jobNames)
 	outputConfig := ProwConfigFile{
-		Periodics: []Periodic{},
+		Periodics:   []Periodic{},
+		Presubmits:  map[string][]Presubmit{},
+		Postsubmits: map[string][]Postsubmit{},
 	}
 	testgridConfig := TestgridConfig{
 		TestGroups: []TestGroup{},
+		Dashboards: []Dashboard{},
 	}
+	dashboardIndex := map[string]int{}
+	genCollector := validate.NewCollector(options.yamlConfigPath)
 	for _, jobName := range jobNames {
-		prow, testgrid := forEachJob(options.outputDir, jobName, config.Jobs[jobName], config)
-		outputConfig.Periodics = append(outputConfig.Periodics, prow)
+		generated, testgrid, err := forEachJob(options.outputDir, jobName, config.Jobs[jobName], config)
+		if err != nil {
+			genCollector.Addf(nil, "job %q: %v", jobName, err)
+			continue
+		}
+		switch generated.Kind {
+		case PresubmitJob:
+			outputConfig.Presubmits[DefaultRepo] = append(outputConfig.Presubmits[DefaultRepo], generated.Presubmit)
+		case PostsubmitJob:
+			outputConfig.Postsubmits[DefaultRepo] = append(outputConfig.Postsubmits[DefaultRepo], generated.Postsubmit)
+		default:
+			outputConfig.Periodics = append(outputConfig.Periodics, generated.Periodic)
+		}
 		if !testgrid.isEmpty() {
 			testgridConfig.TestGroups = append(testgridConfig.TestGroups, testgrid)
+			appendDashboardTabs(&testgridConfig, dashboardIndex, generated.annotations(), testgrid.Name, config.Jobs[jobName])
 		}
 	}
+	if genCollector.HasErrors() {
+		for _, err := range genCollector.Errors {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		log.Fatalf("%d error(s) generating jobs from %s", len(genCollector.Errors), options.yamlConfigPath)
+	}
+	testgridConfig.DashboardGroups = append(testgridConfig.DashboardGroups, buildDashboardGroups(config.DashboardGroups)...)
 	prowfilePath := filepath.Join(options.outputDir, "generated.yaml")
-	// writeConfigToFile(prowfilePath, outputConfig, "")
 	SaveConfigsToFile(outputConfig, prowfilePath)
-	// writeConfigToFile(options.testgridOutputPath, testgridConfig, COMMENT)
+	if options.validateWithProw {
+		if _, err := prowconfig.Load("", prowfilePath, nil, "", nil); err != nil {
+			log.Fatalf("generated prow config failed validation: %v", err)
+		}
+	}
+	if options.testgridOutputPath != "" {
+		writeConfigToFile(options.testgridOutputPath, testgridConfig, COMMENT)
+	}
+}
+
+// validateConfig runs every generate-tests config check against config and
+// returns the aggregated list of problems, each anchored to a file:line
+// when the offending section can be located in the raw yaml.
+func validateConfig(path string, raw []byte, config ConfigFile) validate.Errors {
+	collector := validate.NewCollector(path)
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		collector.Addf(nil, "failed to parse yaml for line-accurate validation: %v", err)
+		return collector.Errors
+	}
+	jobNodes := mapChildNodes(&doc, "jobs")
+	cloudProviderNodes := mapChildNodes(&doc, "cloudProviders")
+	imageNodes := mapChildNodes(&doc, "images")
+	k8sVersionNodes := mapChildNodes(&doc, "k8sVersions")
+	testSuiteNodes := mapChildNodes(&doc, "testSuites")
+
+	keys := validate.Keys{
+		CloudProviders:  keySet(config.CloudProviders),
+		Images:          keySet(config.Images),
+		K8SVersions:     keySet(config.K8SVersions),
+		NodeK8SVersions: keySet(config.NodeK8SVersions),
+		TestSuites:      keySet(config.TestSuites),
+	}
+	for jobName, job := range config.Jobs {
+		node := jobNodes[jobName]
+		validate.CheckJobNameTokens(collector, node, jobName, keys)
+		for _, arg := range job.Args {
+			validate.CheckArg(collector, node, arg)
+		}
+		if isPeriodicJobName(jobName) && job.Interval == "" && job.Cron == "" {
+			collector.Addf(node, "job %q: must set either interval or cron", jobName)
+		}
+	}
+	for _, arg := range config.Common.Args {
+		validate.CheckArg(collector, nil, arg)
+	}
+	for name, cloudProvider := range config.CloudProviders {
+		for _, arg := range cloudProvider.Args {
+			validate.CheckArg(collector, cloudProviderNodes[name], arg)
+		}
+	}
+	for name, image := range config.Images {
+		for _, arg := range image.Args {
+			validate.CheckArg(collector, imageNodes[name], arg)
+		}
+	}
+	for name, k8sVersion := range config.K8SVersions {
+		for _, arg := range k8sVersion.Args {
+			validate.CheckArg(collector, k8sVersionNodes[name], arg)
+		}
+	}
+	for name, testSuite := range config.TestSuites {
+		for _, arg := range testSuite.Args {
+			validate.CheckArg(collector, testSuiteNodes[name], arg)
+		}
+	}
+	return collector.Errors
+}
+
+// mapChildNodes returns the name -> node mapping for the entries of the
+// top-level yaml section called key, e.g. mapChildNodes(doc, "jobs") maps
+// each job name to the yaml.Node holding its definition.
+func mapChildNodes(doc *yaml.Node, key string) map[string]*yaml.Node {
+	result := map[string]*yaml.Node{}
+	if len(doc.Content) == 0 {
+		return result
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return result
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != key {
+			continue
+		}
+		section := root.Content[i+1]
+		if section.Kind != yaml.MappingNode {
+			return result
+		}
+		for j := 0; j+1 < len(section.Content); j += 2 {
+			result[section.Content[j].Value] = section.Content[j+1]
+		}
+	}
+	return result
+}
+
+func keySet[V any](m map[string]V) map[string]struct{} {
+	set := make(map[string]struct{}, len(m))
+	for k := range m {
+		set[k] = struct{}{}
+	}
+	return set
+}
+
+// isPeriodicJobName reports whether jobName names a periodic (e2e or
+// e2enode) job, i.e. one that must carry an interval or cron schedule
+// rather than being presubmit/postsubmit triggered.
+func isPeriodicJobName(jobName string) bool {
+	fields := strings.Split(jobName, "-")
+	if len(fields) < 3 {
+		return false
+	}
+	switch fields[2] {
+	case "e2e", "e2enode":
+		return true
+	default:
+		return false
+	}
+}
+
+// annotations returns the Annotations map of whichever variant of
+// GeneratedJob was populated.
+func (g *GeneratedJob) annotations() map[string]string {
+	switch g.Kind {
+	case PresubmitJob:
+		return g.Presubmit.Annotations
+	case PostsubmitJob:
+		return g.Postsubmit.Annotations
+	default:
+		return g.Periodic.Annotations
+	}
+}
+
+// buildDashboardGroups converts a config's dashboardGroups section into the
+// DashboardGroup entries the testgrid config expects.
+func buildDashboardGroups(entries []DashboardGroupEntry) []DashboardGroup {
+	groups := make([]DashboardGroup, 0, len(entries))
+	for _, entry := range entries {
+		groups = append(groups, DashboardGroup{
+			Name:           entry.Name,
+			DashboardNames: entry.Dashboards,
+		})
+	}
+	return groups
+}
+
+// appendDashboardTabs materializes every dashboard named in the
+// "testgrid-dashboards" annotation as a Dashboard entry with a DashboardTab
+// pointing back at testGroupName, creating the Dashboard the first time its
+// name is seen and appending to it on every subsequent job. dashboardIndex
+// tracks each dashboard's position in testgridConfig.Dashboards.
+func appendDashboardTabs(testgridConfig *TestgridConfig, dashboardIndex map[string]int, annotations map[string]string, testGroupName string, job Job) {
+	dashboardsAnnotation := annotations["testgrid-dashboards"]
+	if dashboardsAnnotation == "" {
+		return
+	}
+	tabName := annotations["testgrid-tab-name"]
+	if tabName == "" {
+		tabName = testGroupName
+	}
+	var alertOptions *AlertOptions
+	if len(job.AlertEmails) != 0 || job.NumPassesToDisableAlert != 0 {
+		alertOptions = &AlertOptions{
+			AlertMailToAddresses:    strings.Join(job.AlertEmails, ","),
+			NumPassesToDisableAlert: job.NumPassesToDisableAlert,
+		}
+	}
+	tab := DashboardTab{
+		Name:          tabName,
+		TestGroupName: testGroupName,
+		ShortText:     job.ShortText,
+		AlertOptions:  alertOptions,
+	}
+	for _, name := range strings.Split(dashboardsAnnotation, ", ") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if idx, ok := dashboardIndex[name]; ok {
+			testgridConfig.Dashboards[idx].DashboardTab = append(testgridConfig.Dashboards[idx].DashboardTab, tab)
+			continue
+		}
+		dashboardIndex[name] = len(testgridConfig.Dashboards)
+		testgridConfig.Dashboards = append(testgridConfig.Dashboards, Dashboard{
+			Name:         name,
+			DashboardTab: []DashboardTab{tab},
+		})
+	}
 }
 
 func writeConfigToFile(outputFile string, config interface{}, comment string) {
@@ -119,46 +351,384 @@ func writeConfigToFile(outputFile string, config interface{}, comment string) {
 	}
 }
 
-func SaveConfigsToFile(data interface{}, outputFilePath string) {
-	tmpt, err := template.ParseFS(testTemplateFS, "test.template.yml")
-	if err != nil {
-		log.Fatalf("fail to Parse ConfigFile Template: , %+v", err)
-	}
-	var buf bytes.Buffer
-	err = tmpt.Execute(&buf, data)
+// SaveConfigsToFile converts data to the upstream prow/config.JobConfig
+// shape and marshals it directly, instead of rendering it through a yaml
+// text/template: a typo in a template produces broken yaml, a missing
+// mapping here is a compile error.
+func SaveConfigsToFile(data ProwConfigFile, outputFilePath string) {
+	jobConfig := toProwJobConfig(data)
+	out, err := kyaml.Marshal(jobConfig)
 	if err != nil {
-		log.Fatalf("fail to render configs struct to yaml template, %+v", err)
+		log.Fatalf("fail to marshal configs struct to yaml, %+v", err)
 	}
 	log.Println("writing result output config file")
-	if err := os.WriteFile(outputFilePath, buf.Bytes(), 0o600); err != nil {
+	if err := os.WriteFile(outputFilePath, out, 0o600); err != nil {
 		log.Fatalf("fail to write configs struct to yaml, %s", err)
 	}
 }
 
-func forEachJob(outputDir string, jobName string, job Job, config ConfigFile) (Periodic, TestGroup) {
+// toProwJobConfig maps our internal ProwConfigFile onto prow/config's own
+// JobConfig, the type Prow itself loads and validates.
+func toProwJobConfig(data ProwConfigFile) *prowconfig.JobConfig {
+	jobConfig := &prowconfig.JobConfig{
+		Periodics: make([]prowconfig.Periodic, 0, len(data.Periodics)),
+	}
+	for _, p := range data.Periodics {
+		jobConfig.Periodics = append(jobConfig.Periodics, toProwPeriodic(p))
+	}
+	if len(data.Presubmits) != 0 {
+		jobConfig.PresubmitsStatic = make(map[string][]prowconfig.Presubmit, len(data.Presubmits))
+		for repo, presubmits := range data.Presubmits {
+			for _, p := range presubmits {
+				jobConfig.PresubmitsStatic[repo] = append(jobConfig.PresubmitsStatic[repo], toProwPresubmit(p))
+			}
+		}
+	}
+	if len(data.Postsubmits) != 0 {
+		jobConfig.PostsubmitsStatic = make(map[string][]prowconfig.Postsubmit, len(data.Postsubmits))
+		for repo, postsubmits := range data.Postsubmits {
+			for _, p := range postsubmits {
+				jobConfig.PostsubmitsStatic[repo] = append(jobConfig.PostsubmitsStatic[repo], toProwPostsubmit(p))
+			}
+		}
+	}
+	return jobConfig
+}
+
+func toProwJobBase(name string, tags []string, labels map[string]string, decorate bool, decorationConfig DecorationConfig, spec Spec, cluster string, annotations map[string]string, prowOptions ProwOptions) prowconfig.JobBase {
+	base := prowconfig.JobBase{
+		Name:           name,
+		Labels:         labels,
+		Annotations:    annotations,
+		Cluster:        cluster,
+		Spec:           toProwPodSpec(spec),
+		MaxConcurrency: prowOptions.MaxConcurrency,
+		UtilityConfig: prowconfig.UtilityConfig{
+			Decorate:         &decorate,
+			DecorationConfig: toProwDecorationConfig(decorationConfig),
+			PathAlias:        prowOptions.PathAlias,
+			ExtraRefs:        toProwExtraRefs(prowOptions.ExtraRefs),
+		},
+	}
+	if len(tags) != 0 {
+		if base.Labels == nil {
+			base.Labels = map[string]string{}
+		}
+		base.Labels["preset-tags"] = strings.Join(tags, ",")
+	}
+	return base
+}
+
+func toProwPeriodic(p Periodic) prowconfig.Periodic {
+	return prowconfig.Periodic{
+		JobBase:  toProwJobBase(p.Name, p.Tags, p.Labels, p.Decorate, p.DecorationConfig, p.Spec, p.Cluster, p.Annotations, p.ProwOptions),
+		Cron:     p.Cron,
+		Interval: p.Interval,
+	}
+}
+
+func toProwPresubmit(p Presubmit) prowconfig.Presubmit {
+	return prowconfig.Presubmit{
+		JobBase:      toProwJobBase(p.Name, p.Tags, p.Labels, p.Decorate, p.DecorationConfig, p.Spec, p.Cluster, p.Annotations, p.ProwOptions),
+		AlwaysRun:    p.AlwaysRun,
+		Optional:     p.Optional,
+		Trigger:      p.Trigger,
+		RerunCommand: p.RerunCommand,
+		Brancher: prowconfig.Brancher{
+			Branches:     p.Branches,
+			SkipBranches: p.SkipBranches,
+		},
+		Reporter: prowconfig.Reporter{
+			Context:    p.Context,
+			SkipReport: !p.Report,
+		},
+	}
+}
+
+func toProwPostsubmit(p Postsubmit) prowconfig.Postsubmit {
+	return prowconfig.Postsubmit{
+		JobBase: toProwJobBase(p.Name, p.Tags, p.Labels, p.Decorate, p.DecorationConfig, p.Spec, p.Cluster, p.Annotations, p.ProwOptions),
+		Brancher: prowconfig.Brancher{
+			Branches:     p.Branches,
+			SkipBranches: p.SkipBranches,
+		},
+		Reporter: prowconfig.Reporter{
+			Context:    p.Context,
+			SkipReport: !p.Report,
+		},
+	}
+}
+
+func toProwDecorationConfig(d DecorationConfig) *prowapi.DecorationConfig {
+	if d.Timeout == "" {
+		return nil
+	}
+	timeout, err := time.ParseDuration(d.Timeout)
+	if err != nil {
+		log.Fatalf("invalid decoration_config.timeout %q: %v", d.Timeout, err)
+	}
+	return &prowapi.DecorationConfig{Timeout: &prowapi.Duration{Duration: timeout}}
+}
+
+func toProwExtraRefs(refs []ExtraRef) []prowapi.Refs {
+	if len(refs) == 0 {
+		return nil
+	}
+	result := make([]prowapi.Refs, 0, len(refs))
+	for _, ref := range refs {
+		result = append(result, prowapi.Refs{
+			Org:     ref.Org,
+			Repo:    ref.Repo,
+			BaseRef: ref.BaseRef,
+			WorkDir: ref.WorkDir,
+		})
+	}
+	return result
+}
+
+func toProwPodSpec(spec Spec) *corev1.PodSpec {
+	podSpec := &corev1.PodSpec{
+		Containers:         toProwContainers(spec.Containers),
+		NodeSelector:       spec.NodeSelector,
+		ServiceAccountName: spec.ServiceAccount,
+		Volumes:            toProwVolumes(spec.Volumes),
+		Tolerations:        toProwTolerations(spec.Tolerations),
+	}
+	return podSpec
+}
+
+func toProwContainers(containers []Container) []corev1.Container {
+	result := make([]corev1.Container, 0, len(containers))
+	for _, c := range containers {
+		container := corev1.Container{
+			Command:         c.Command,
+			Args:            c.Args,
+			Image:           c.Image,
+			Resources:       toProwResourceRequirements(c.Resources),
+			Env:             toProwEnvVars(c.Env),
+			VolumeMounts:    toProwVolumeMounts(c.VolumeMounts),
+			SecurityContext: toProwSecurityContext(c.SecurityContext),
+		}
+		result = append(result, container)
+	}
+	return result
+}
+
+func toProwResourceRequirements(r Resources) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: toProwResourceList(r.Requests),
+		Limits:   toProwResourceList(r.Limits),
+	}
+}
+
+func toProwResourceList(cr ComputeResources) corev1.ResourceList {
+	list := corev1.ResourceList{}
+	if cr.CPU != "" {
+		list[corev1.ResourceCPU] = resource.MustParse(cr.CPU)
+	}
+	if cr.Memory != "" {
+		list[corev1.ResourceMemory] = resource.MustParse(cr.Memory)
+	}
+	return list
+}
+
+func toProwSecurityContext(sc *SecurityContext) *corev1.SecurityContext {
+	if sc == nil {
+		return nil
+	}
+	return &corev1.SecurityContext{
+		RunAsUser:    sc.RunAsUser,
+		RunAsNonRoot: sc.RunAsNonRoot,
+	}
+}
+
+func toProwEnvVars(envVars []EnvVar) []corev1.EnvVar {
+	if len(envVars) == 0 {
+		return nil
+	}
+	result := make([]corev1.EnvVar, 0, len(envVars))
+	for _, e := range envVars {
+		result = append(result, corev1.EnvVar{Name: e.Name, Value: e.Value})
+	}
+	return result
+}
+
+func toProwVolumeMounts(mounts []VolumeMount) []corev1.VolumeMount {
+	if len(mounts) == 0 {
+		return nil
+	}
+	result := make([]corev1.VolumeMount, 0, len(mounts))
+	for _, m := range mounts {
+		result = append(result, corev1.VolumeMount{Name: m.Name, MountPath: m.MountPath, ReadOnly: m.ReadOnly})
+	}
+	return result
+}
+
+func toProwVolumes(volumes []Volume) []corev1.Volume {
+	if len(volumes) == 0 {
+		return nil
+	}
+	result := make([]corev1.Volume, 0, len(volumes))
+	for _, v := range volumes {
+		volume := corev1.Volume{Name: v.Name}
+		if v.EmptyDir {
+			volume.VolumeSource = corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+		}
+		result = append(result, volume)
+	}
+	return result
+}
+
+func toProwTolerations(tolerations []Toleration) []corev1.Toleration {
+	if len(tolerations) == 0 {
+		return nil
+	}
+	result := make([]corev1.Toleration, 0, len(tolerations))
+	for _, t := range tolerations {
+		result = append(result, corev1.Toleration{
+			Key:      t.Key,
+			Operator: corev1.TolerationOperator(t.Operator),
+			Value:    t.Value,
+			Effect:   corev1.TaintEffect(t.Effect),
+		})
+	}
+	return result
+}
+
+// JobKind identifies which Prow job flavor a generated job should be
+// emitted as.
+type JobKind int
+
+const (
+	PeriodicJob JobKind = iota
+	PresubmitJob
+	PostsubmitJob
+)
+
+// GeneratedJob holds the output of forEachJob. Only the field matching
+// Kind is populated.
+type GeneratedJob struct {
+	Kind       JobKind
+	Periodic   Periodic
+	Presubmit  Presubmit
+	Postsubmit Postsubmit
+}
+
+// forEachJob assumes jobName has already passed validateConfig (field
+// count, token cross-references, arg well-formedness); the errors it
+// returns below are a last-resort safety net, not the primary way config
+// problems are reported, for a caller (e.g. a test) that bypasses
+// validateConfig.
+func forEachJob(outputDir string, jobName string, job Job, config ConfigFile) (GeneratedJob, TestGroup, error) {
 	var jobConfig Job
-	var prowConfig Periodic
+	var generated GeneratedJob
 	var testgridConfig TestGroup
 	fields := strings.Split(jobName, "-")
 	if len(fields) < 3 {
-		log.Fatalln("Expected at least 3 fields in job name", jobName)
+		return GeneratedJob{}, TestGroup{}, fmt.Errorf("expected at least 3 fields in job name %q", jobName)
 	}
 	jobType := fields[2]
 	switch jobType {
 	case "e2e":
-		e2eTest := newE2ETest(outputDir, jobName, job, config)
-		jobConfig, prowConfig, testgridConfig = e2eTest.generate()
+		e2eTest, err := newE2ETest(outputDir, jobName, job, config)
+		if err != nil {
+			return GeneratedJob{}, TestGroup{}, err
+		}
+		jobConfig, generated.Periodic, testgridConfig, err = e2eTest.generate()
+		if err != nil {
+			return GeneratedJob{}, TestGroup{}, err
+		}
+		generated.Kind = PeriodicJob
+	case "e2epr":
+		e2eTest, err := newE2ETest(outputDir, jobName, job, config)
+		if err != nil {
+			return GeneratedJob{}, TestGroup{}, err
+		}
+		jobConfig, generated.Presubmit, testgridConfig, err = e2eTest.generatePresubmit()
+		if err != nil {
+			return GeneratedJob{}, TestGroup{}, err
+		}
+		generated.Kind = PresubmitJob
+	case "e2epostmerge":
+		e2eTest, err := newE2ETest(outputDir, jobName, job, config)
+		if err != nil {
+			return GeneratedJob{}, TestGroup{}, err
+		}
+		jobConfig, generated.Postsubmit, testgridConfig, err = e2eTest.generatePostsubmit()
+		if err != nil {
+			return GeneratedJob{}, TestGroup{}, err
+		}
+		generated.Kind = PostsubmitJob
 	case "e2enode":
-		e2eNodeTest := newE2ENodeTest(jobName, job, config)
-		jobConfig, prowConfig = e2eNodeTest.generate()
+		e2eNodeTest, err := newE2ENodeTest(jobName, job, config)
+		if err != nil {
+			return GeneratedJob{}, TestGroup{}, err
+		}
+		jobConfig, generated.Periodic, testgridConfig, err = e2eNodeTest.generate()
+		if err != nil {
+			return GeneratedJob{}, TestGroup{}, err
+		}
+		generated.Kind = PeriodicJob
 	default:
-		log.Fatalf("Job %s has unexpected job type %s", jobName, jobType)
+		return GeneratedJob{}, TestGroup{}, fmt.Errorf("job %s has unexpected job type %s", jobName, jobType)
 	}
 	jobConfig.Args = applyJobOverrides(jobConfig.Args, getArgs(jobName, job.Args))
-	prowConfig.Spec.Containers[0].Args = append(prowConfig.Spec.Containers[0].Args, jobConfig.Args...)
-	file := fmt.Sprintf("/workspace/scenarios/%s.py", jobConfig.Scenario)
-	prowConfig.Spec.Containers[0].Command = []string{"runner.sh", file}
-	return prowConfig, testgridConfig
+	command, args := buildRunnerCommand(jobType, job, jobConfig, config, fields)
+	switch generated.Kind {
+	case PresubmitJob:
+		generated.Presubmit.Spec.Containers[0].Args = append(generated.Presubmit.Spec.Containers[0].Args, args...)
+		generated.Presubmit.Spec.Containers[0].Command = command
+	case PostsubmitJob:
+		generated.Postsubmit.Spec.Containers[0].Args = append(generated.Postsubmit.Spec.Containers[0].Args, args...)
+		generated.Postsubmit.Spec.Containers[0].Command = command
+	default:
+		generated.Periodic.Spec.Containers[0].Args = append(generated.Periodic.Spec.Containers[0].Args, args...)
+		generated.Periodic.Spec.Containers[0].Command = command
+	}
+	return generated, testgridConfig, nil
+}
+
+// buildRunnerCommand returns the container Command and Args for jobConfig.
+// The default "scenario" runner drives kubernetes_e2e.py through
+// runner.sh; "kubetest2" (e2e/e2epr/e2epostmerge jobs only) instead
+// translates jobConfig.Args into kubetest2's
+// "<deployer> --up --down --test=<tester> -- <tester args>" form.
+func buildRunnerCommand(nameJobType string, job Job, jobConfig Job, config ConfigFile, fields []string) ([]string, []string) {
+	isKubetest2 := job.Runner == "kubetest2" &&
+		(nameJobType == "e2e" || nameJobType == "e2epr" || nameJobType == "e2epostmerge")
+	if !isKubetest2 {
+		command := []string{"runner.sh", fmt.Sprintf("/workspace/scenarios/%s.py", jobConfig.Scenario)}
+		return command, jobConfig.Args
+	}
+	cloudProvider := config.CloudProviders[fields[3]]
+	testSuite := config.TestSuites[fields[6]]
+	return []string{"kubetest2"}, kubetest2Args(jobConfig.Args, cloudProvider.Kubetest2, testSuite.Kubetest2)
+}
+
+// kubetest2Args translates a merged kubernetes_e2e.py-style arg list into
+// the kubetest2 invocation form.
+func kubetest2Args(args []string, deployer Kubetest2Config, tester Kubetest2Config) []string {
+	testerName := tester.Tester
+	if testerName == "" {
+		testerName = "ginkgo"
+	}
+	result := []string{deployer.Deployer}
+	result = append(result, deployer.DeployerArgs...)
+	result = append(result, tester.DeployerArgs...)
+	result = append(result, "--up", "--down", fmt.Sprintf("--test=%s", testerName))
+	result = append(result, "--")
+	testerArgs := append([]string{}, tester.TesterArgs...)
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--timeout=") {
+			value := strings.TrimSuffix(strings.TrimPrefix(arg, "--timeout="), "m")
+			testerArgs = append(testerArgs, fmt.Sprintf("--test-timeout=%sm", value))
+			continue
+		}
+		testerArgs = append(testerArgs, arg)
+	}
+	testerArgs = append(testerArgs, deployer.TesterArgs...)
+	result = append(result, testerArgs...)
+	return result
 }
 
 func applyJobOverrides(envsOrArgs []string, jobEnvsOrArgs []string) []string {
@@ -204,11 +774,16 @@ func getArgs(jobName string, args []string) []string {
 	return substitute(jobName, args)
 }
 
-func newE2ETest(outputDir string, jobName string, job Job, config ConfigFile) E2ETest {
+// newE2ETest builds an E2ETest from jobName's 7 "-"-separated fields. The
+// field-count error below is an invariant enforced upstream by
+// validateConfig (see forEachJob); it is returned rather than fataled so a
+// caller that bypasses validateConfig (e.g. a test) gets it back instead of
+// killing the process.
+func newE2ETest(outputDir string, jobName string, job Job, config ConfigFile) (E2ETest, error) {
 	envFilePath := filepath.Join(outputDir, jobName+".env")
 	fields := strings.Split(jobName, "-")
 	if len(fields) != 7 {
-		log.Fatalln("Expected 7 fields in job name", jobName)
+		return E2ETest{}, fmt.Errorf("expected 7 fields in job name %q, got %d", jobName, len(fields))
 	}
 	return E2ETest{
 		EnvFilename:   envFilePath,
@@ -220,41 +795,96 @@ func newE2ETest(outputDir string, jobName string, job Job, config ConfigFile) E2
 		Image:         config.Images[fields[4]],
 		K8SVersion:    config.K8SVersions[fields[5][3:]],
 		TestSuite:     config.TestSuites[fields[6]],
+	}, nil
+}
+
+// buildArgs assembles the common -> cloud provider -> image -> k8s version
+// -> test suite argument chain shared by every E2ETest variant.
+func (et *E2ETest) buildArgs() []string {
+	args := []string{}
+	args = append(args, getArgs(et.JobName, et.Common.Args)...)
+	args = append(args, getArgs(et.JobName, et.CloudProvider.Args)...)
+	args = append(args, getArgs(et.JobName, et.Image.Args)...)
+	args = append(args, getArgs(et.JobName, et.K8SVersion.Args)...)
+	args = append(args, getArgs(et.JobName, et.TestSuite.Args)...)
+	return args
+}
+
+// dashboards returns the testgrid-dashboards entries for this E2ETest.
+func (et *E2ETest) dashboards() []string {
+	if et.Job.TestgridDashboardOverride != "" {
+		return []string{et.Job.TestgridDashboardOverride}
+	}
+	dashboards := et.InitializeDashBoardsWithReleaseBlockingInfo(et.K8SVersion.Version)
+	if et.Image.TestgridPrefix != "" {
+		dashboard := fmt.Sprintf("%s-%s-%s", et.Image.TestgridPrefix, et.fields[4], et.fields[5])
+		dashboards = append(dashboards, dashboard)
+	}
+	return dashboards
+}
+
+func (et *E2ETest) tabName() string {
+	return fmt.Sprintf("%s-%s-%s-%s", et.fields[3], et.fields[4], et.fields[5], et.fields[6])
+}
+
+// annotate stamps the testgrid annotations shared by every variant of this
+// E2ETest onto annotations, creating the map if necessary.
+func (et *E2ETest) annotate(annotations map[string]string) map[string]string {
+	if annotations == nil {
+		annotations = map[string]string{}
 	}
+	annotations["testgrid-tab-name"] = et.tabName()
+	annotations["testgrid-dashboards"] = strings.Join(et.dashboards(), ", ")
+	annotations["testgrid-num-failures-to-alert"] = strconv.Itoa(et.Job.TestgridNumFailuresToAlert)
+	return annotations
 }
 
-func (et *E2ETest) generate() (Job, Periodic, TestGroup) {
+func (et *E2ETest) generate() (Job, Periodic, TestGroup, error) {
 	log.Printf("generating e2e job: %s", et.JobName)
 	if len(et.fields) != 7 {
-		log.Fatalln("Expected 7 fields in job name", et.JobName)
+		return Job{}, Periodic{}, TestGroup{}, fmt.Errorf("expected 7 fields in job name %q, got %d", et.JobName, len(et.fields))
 	}
-	image := et.Image
-	cloudProvider := et.CloudProvider
-	K8SVersion := et.K8SVersion
-	testSuite := et.TestSuite
-	args := []string{}
-	args = append(args, getArgs(et.JobName, et.Common.Args)...)
-	args = append(args, getArgs(et.JobName, cloudProvider.Args)...)
-	args = append(args, getArgs(et.JobName, image.Args)...)
-	args = append(args, getArgs(et.JobName, K8SVersion.Args)...)
-	args = append(args, getArgs(et.JobName, testSuite.Args)...)
+	args := et.buildArgs()
+	jobConfig := et.getJobDefinition(args)
+	prowConfig, err := et.getProwConfig(et.TestSuite)
+	if err != nil {
+		return Job{}, Periodic{}, TestGroup{}, err
+	}
+	tgConfig := et.getTestGridConfig()
+	prowConfig.Annotations = et.annotate(prowConfig.Annotations)
+	return jobConfig, prowConfig, tgConfig, nil
+}
 
+func (et *E2ETest) generatePresubmit() (Job, Presubmit, TestGroup, error) {
+	log.Printf("generating e2e presubmit job: %s", et.JobName)
+	if len(et.fields) != 7 {
+		return Job{}, Presubmit{}, TestGroup{}, fmt.Errorf("expected 7 fields in job name %q, got %d", et.JobName, len(et.fields))
+	}
+	args := et.buildArgs()
 	jobConfig := et.getJobDefinition(args)
-	prowConfig := et.getProwConfig(testSuite)
+	prowConfig, err := et.getPresubmitConfig(et.TestSuite)
+	if err != nil {
+		return Job{}, Presubmit{}, TestGroup{}, err
+	}
 	tgConfig := et.getTestGridConfig()
-	tabName := fmt.Sprintf("%s-%s-%s-%s", et.fields[3], et.fields[4], et.fields[5], et.fields[6])
-	if prowConfig.Annotations == nil {
-		prowConfig.Annotations = map[string]string{}
+	prowConfig.Annotations = et.annotate(prowConfig.Annotations)
+	return jobConfig, prowConfig, tgConfig, nil
+}
+
+func (et *E2ETest) generatePostsubmit() (Job, Postsubmit, TestGroup, error) {
+	log.Printf("generating e2e postsubmit job: %s", et.JobName)
+	if len(et.fields) != 7 {
+		return Job{}, Postsubmit{}, TestGroup{}, fmt.Errorf("expected 7 fields in job name %q, got %d", et.JobName, len(et.fields))
 	}
-	prowConfig.Annotations["testgrid-tab-name"] = tabName
-	dashboards := et.InitializeDashBoardsWithReleaseBlockingInfo(K8SVersion.Version)
-	if image.TestgridPrefix != "" {
-		dashboard := fmt.Sprintf("%s-%s-%s", image.TestgridPrefix, et.fields[4], et.fields[5])
-		dashboards = append(dashboards, dashboard)
+	args := et.buildArgs()
+	jobConfig := et.getJobDefinition(args)
+	prowConfig, err := et.getPostsubmitConfig(et.TestSuite)
+	if err != nil {
+		return Job{}, Postsubmit{}, TestGroup{}, err
 	}
-	prowConfig.Annotations["testgrid-dashboards"] = strings.Join(dashboards, ", ")
-	prowConfig.Annotations["testgrid-num-failures-to-alert"] = strconv.Itoa(et.Job.TestgridNumFailuresToAlert)
-	return jobConfig, prowConfig, tgConfig
+	tgConfig := et.getTestGridConfig()
+	prowConfig.Annotations = et.annotate(prowConfig.Annotations)
+	return jobConfig, prowConfig, tgConfig, nil
 }
 
 func (et *E2ETest) InitializeDashBoardsWithReleaseBlockingInfo(version string) []string {
@@ -303,7 +933,95 @@ func (et *E2ETest) getTestGridConfig() TestGroup {
 	}
 }
 
-func (et *E2ETest) getProwConfig(testSuite TestSuite) Periodic {
+func (et *E2ETest) baseContainerSpec(testSuite TestSuite) Spec {
+	spec := Spec{
+		Containers: []Container{
+			{
+				Image: "gcr.io/k8s-staging-test-infra/kubekins-e2e:v20231206-f7b83ffbe6-master",
+				Resources: Resources{
+					Requests: ComputeResources{
+						CPU:    "1000m",
+						Memory: "3Gi",
+					},
+					Limits: ComputeResources{
+						CPU:    "1000m",
+						Memory: "3Gi",
+					},
+				},
+				Args: []string{},
+			},
+		},
+	}
+	if !testSuite.Resources.isEmpty() {
+		spec.Containers[0].Resources = testSuite.Resources
+	} else if !et.Job.Resources.isEmpty() {
+		spec.Containers[0].Resources = et.Job.Resources
+	}
+	return applyPodSpecExtensions(spec, et.Job)
+}
+
+// applyPodSpecExtensions layers the optional pod/container-level fields from
+// a Job's YAML config onto a generated Spec.
+func applyPodSpecExtensions(spec Spec, job Job) Spec {
+	if len(job.Env) > 0 {
+		spec.Containers[0].Env = job.Env
+	}
+	if len(job.VolumeMounts) > 0 {
+		spec.Containers[0].VolumeMounts = job.VolumeMounts
+	}
+	if job.SecurityContext != nil {
+		spec.Containers[0].SecurityContext = job.SecurityContext
+	}
+	if len(job.NodeSelector) > 0 {
+		spec.NodeSelector = job.NodeSelector
+	}
+	if len(job.Tolerations) > 0 {
+		spec.Tolerations = job.Tolerations
+	}
+	if len(job.Volumes) > 0 {
+		spec.Volumes = job.Volumes
+	}
+	if job.ServiceAccount != "" {
+		spec.ServiceAccount = job.ServiceAccount
+	}
+	return spec
+}
+
+// prowOptionsFromJob layers the optional Prow-level (non-pod-spec) knobs
+// from a Job's YAML config onto a generated ProwOptions.
+func prowOptionsFromJob(job Job) ProwOptions {
+	return ProwOptions{
+		MaxConcurrency: job.MaxConcurrency,
+		ExtraRefs:      job.ExtraRefs,
+		PathAlias:      job.PathAlias,
+	}
+}
+
+func (et *E2ETest) timeoutDecoration(testSuite TestSuite) (DecorationConfig, error) {
+	// The source YAML always authors the test's own timeout as
+	// --timeout=<int>m, even for kubetest2 jobs: the --timeout ->
+	// --test-timeout rewrite only happens later, in kubetest2Args, on the
+	// merged runtime arg list. Prow decoration adds 20 minutes on top.
+	const prefix = "--timeout="
+	var timeout int
+	var err error
+	for _, arg := range testSuite.Args {
+		if strings.HasPrefix(arg, prefix) {
+			value := strings.TrimSuffix(strings.TrimPrefix(arg, prefix), "m")
+			timeout, err = strconv.Atoi(value)
+			if err != nil {
+				// validateConfig's CheckArg already rejects a malformed
+				// --timeout value in every test suite's args, so this is
+				// unreachable in normal operation.
+				return DecorationConfig{}, fmt.Errorf("error, parsing timeout of job: %s, %s", et.JobName, err)
+			}
+			break
+		}
+	}
+	return DecorationConfig{Timeout: fmt.Sprintf("%vm", timeout+20)}, nil
+}
+
+func (et *E2ETest) getProwConfig(testSuite TestSuite) (Periodic, error) {
 	prowConfig := Periodic{
 		Name: et.JobName,
 		Tags: []string{"generated"},
@@ -311,39 +1029,15 @@ func (et *E2ETest) getProwConfig(testSuite TestSuite) Periodic {
 			"preset-service-account": "true",
 			"preset-k8s-ssh":         "true",
 		},
-		Decorate: true,
-		DecorationConfig: DecorationConfig{
-			Timeout: "180m",
-		},
-		Spec: Spec{
-			Containers: []Container{
-				{
-					Image: "gcr.io/k8s-staging-test-infra/kubekins-e2e:v20231206-f7b83ffbe6-master",
-					Resources: Resources{
-						Requests: ComputeResources{
-							CPU:    "1000m",
-							Memory: "3Gi",
-						},
-						Limits: ComputeResources{
-							CPU:    "1000m",
-							Memory: "3Gi",
-						},
-					},
-					Args: []string{},
-				},
-			},
-		},
+		Decorate:    true,
+		Spec:        et.baseContainerSpec(testSuite),
+		ProwOptions: prowOptionsFromJob(et.Job),
 	}
 	if testSuite.Cluster != "" {
 		prowConfig.Cluster = testSuite.Cluster
 	} else if et.Job.Cluster != "" {
 		prowConfig.Cluster = et.Job.Cluster
 	}
-	if !testSuite.Resources.isEmpty() {
-		prowConfig.Spec.Containers[0].Resources = testSuite.Resources
-	} else if !et.Job.Resources.isEmpty() {
-		prowConfig.Spec.Containers[0].Resources = et.Job.Resources
-	}
 	// Possible weird assumtion
 	if et.Job.Interval != "" {
 		prowConfig.Cron = ""
@@ -352,30 +1046,95 @@ func (et *E2ETest) getProwConfig(testSuite TestSuite) Periodic {
 		prowConfig.Interval = ""
 		prowConfig.Cron = et.Job.Cron
 	} else {
-		log.Fatalln("No interval or cron definition found")
+		// validateConfig requires every periodic job to set interval or
+		// cron, so this is unreachable in normal operation.
+		return Periodic{}, fmt.Errorf("job %s: no interval or cron definition found", et.JobName)
 	}
-	// Assumes that the value in --timeout is of minutes.
-	var timeout int
-	var err error
-	for _, arg := range testSuite.Args {
-		if strings.HasPrefix(arg, "--timeout=") {
-			value := arg[10 : len(arg)-1]
-			timeout, err = strconv.Atoi(value)
-			if err != nil {
-				log.Fatalf("error, parsing timeout of job: %s, %s", et.JobName, err)
-			}
-			break
-		}
+	decoration, err := et.timeoutDecoration(testSuite)
+	if err != nil {
+		return Periodic{}, err
 	}
-	newTimeout := fmt.Sprintf("%vm", timeout+20)
-	prowConfig.DecorationConfig.Timeout = newTimeout
-	return prowConfig
+	prowConfig.DecorationConfig = decoration
+	return prowConfig, nil
+}
+
+// getPresubmitConfig builds the PR-triggered variant of this E2ETest. Unlike
+// periodics, presubmits aren't scheduled: Prow runs them on every PR
+// (always_run) and in response to /test comments (trigger/rerun_command).
+func (et *E2ETest) getPresubmitConfig(testSuite TestSuite) (Presubmit, error) {
+	prowConfig := Presubmit{
+		Name: et.JobName,
+		Tags: []string{"generated"},
+		Labels: map[string]string{
+			"preset-service-account": "true",
+			"preset-k8s-ssh":         "true",
+		},
+		Decorate:     true,
+		Spec:         et.baseContainerSpec(testSuite),
+		ProwOptions:  prowOptionsFromJob(et.Job),
+		AlwaysRun:    !et.Job.Optional,
+		Optional:     et.Job.Optional,
+		Trigger:      fmt.Sprintf(`(?m)^/test (?:.*? )?%s(?: .*?)?$`, et.JobName),
+		RerunCommand: fmt.Sprintf("/test %s", et.JobName),
+		Context:      et.JobName,
+		Report:       true,
+		Branches:     et.Job.Branches,
+		SkipBranches: et.Job.SkipBranches,
+	}
+	if testSuite.Cluster != "" {
+		prowConfig.Cluster = testSuite.Cluster
+	} else if et.Job.Cluster != "" {
+		prowConfig.Cluster = et.Job.Cluster
+	}
+	decoration, err := et.timeoutDecoration(testSuite)
+	if err != nil {
+		return Presubmit{}, err
+	}
+	prowConfig.DecorationConfig = decoration
+	return prowConfig, nil
+}
+
+// getPostsubmitConfig builds the post-merge variant of this E2ETest.
+// Postsubmits run automatically after a merge to matching branches, so they
+// carry no always_run/optional/trigger/rerun_command fields.
+func (et *E2ETest) getPostsubmitConfig(testSuite TestSuite) (Postsubmit, error) {
+	prowConfig := Postsubmit{
+		Name: et.JobName,
+		Tags: []string{"generated"},
+		Labels: map[string]string{
+			"preset-service-account": "true",
+			"preset-k8s-ssh":         "true",
+		},
+		Decorate:     true,
+		Spec:         et.baseContainerSpec(testSuite),
+		ProwOptions:  prowOptionsFromJob(et.Job),
+		Context:      et.JobName,
+		Report:       true,
+		Branches:     et.Job.Branches,
+		SkipBranches: et.Job.SkipBranches,
+	}
+	if testSuite.Cluster != "" {
+		prowConfig.Cluster = testSuite.Cluster
+	} else if et.Job.Cluster != "" {
+		prowConfig.Cluster = et.Job.Cluster
+	}
+	decoration, err := et.timeoutDecoration(testSuite)
+	if err != nil {
+		return Postsubmit{}, err
+	}
+	prowConfig.DecorationConfig = decoration
+	return prowConfig, nil
 }
 
-func newE2ENodeTest(jobName string, job Job, config ConfigFile) E2ENodeTest {
+// newE2ENodeTest builds an E2ENodeTest from jobName's 6 "-"-separated
+// fields. The field-count error below is an invariant enforced upstream by
+// validateConfig (see forEachJob); it is returned rather than fataled so a
+// caller that bypasses validateConfig (e.g. a test) gets it back instead of
+// killing the process.
+func newE2ENodeTest(jobName string, job Job, config ConfigFile) (E2ENodeTest, error) {
 	fields := strings.Split(jobName, "-")
 	if len(fields) != 6 {
-		log.Fatalln("Expected 6 fields in job name", jobName)
+		return E2ENodeTest{}, fmt.Errorf("expected 6 fields in job name %q, got %d", jobName, len(fields))
 	}
 	return E2ENodeTest{
 		JobName:    jobName,
@@ -385,7 +1144,7 @@ func newE2ENodeTest(jobName string, job Job, config ConfigFile) E2ENodeTest {
 		Image:      config.Images[fields[3]],
 		K8SVersion: config.NodeK8SVersions[fields[4][3:]],
 		TestSuite:  config.TestSuites[fields[5]],
-	}
+	}, nil
 }
 
 func (ent *E2ENodeTest) getJobDefinition(args []string) Job {
@@ -401,7 +1160,7 @@ func (ent *E2ENodeTest) getJobDefinition(args []string) Job {
 	}
 }
 
-func (ent *E2ENodeTest) getProwConfig(testSuite TestSuite, k8sVersion NodeK8SVersion) Periodic {
+func (ent *E2ENodeTest) getProwConfig(testSuite TestSuite, k8sVersion NodeK8SVersion) (Periodic, error) {
 	prowConfig := Periodic{
 		Name: ent.JobName,
 		Tags: []string{"generated"},
@@ -431,6 +1190,7 @@ func (ent *E2ENodeTest) getProwConfig(testSuite TestSuite, k8sVersion NodeK8SVer
 				},
 			},
 		},
+		ProwOptions: prowOptionsFromJob(ent.Job),
 	}
 	if testSuite.Cluster != "" {
 		prowConfig.Cluster = testSuite.Cluster
@@ -442,6 +1202,7 @@ func (ent *E2ENodeTest) getProwConfig(testSuite TestSuite, k8sVersion NodeK8SVer
 	} else if !ent.Job.Resources.isEmpty() {
 		prowConfig.Spec.Containers[0].Resources = ent.Job.Resources
 	}
+	prowConfig.Spec = applyPodSpecExtensions(prowConfig.Spec, ent.Job)
 	// Possible weird assumtion
 	if ent.Job.Interval != "" {
 		prowConfig.Cron = ""
@@ -450,7 +1211,9 @@ func (ent *E2ENodeTest) getProwConfig(testSuite TestSuite, k8sVersion NodeK8SVer
 		prowConfig.Interval = ""
 		prowConfig.Cron = ent.Job.Cron
 	} else {
-		log.Fatalln("No interval or cron definition found")
+		// validateConfig requires every periodic job to set interval or
+		// cron, so this is unreachable in normal operation.
+		return Periodic{}, fmt.Errorf("job %s: no interval or cron definition found", ent.JobName)
 	}
 	// Assumes that the value in --timeout is of minutes.
 	var timeout int
@@ -460,7 +1223,10 @@ func (ent *E2ENodeTest) getProwConfig(testSuite TestSuite, k8sVersion NodeK8SVer
 			value := arg[10 : len(arg)-1]
 			timeout, err = strconv.Atoi(value)
 			if err != nil {
-				log.Fatalf("error, parsing timeout of job: %s, %s", ent.JobName, err)
+				// validateConfig's CheckArg already rejects a malformed
+				// --timeout value, so this is unreachable in normal
+				// operation.
+				return Periodic{}, fmt.Errorf("error, parsing timeout of job: %s, %s", ent.JobName, err)
 			}
 			break
 		}
@@ -476,13 +1242,36 @@ func (ent *E2ENodeTest) getProwConfig(testSuite TestSuite, k8sVersion NodeK8SVer
 	if k8sVersion.ProwImage != "" {
 		prowConfig.Spec.Containers[0].Image = k8sVersion.ProwImage
 	}
-	return prowConfig
+	return prowConfig, nil
+}
+
+// getTestGridConfig mirrors E2ETest.getTestGridConfig so e2enode jobs get
+// a testgrid TestGroup too.
+func (ent *E2ENodeTest) getTestGridConfig() TestGroup {
+	return TestGroup{
+		Name:      ent.JobName,
+		GCSPrefix: GCSLOGPREFIX + ent.JobName,
+		ColumnHeader: []ConfigurationValue{
+			{
+				ConfigurationValue: "node_os_image",
+			},
+			{
+				ConfigurationValue: "master_os_image",
+			},
+			{
+				ConfigurationValue: "Commit",
+			},
+			{
+				ConfigurationValue: "infra-commit",
+			},
+		},
+	}
 }
 
-func (ent *E2ENodeTest) generate() (Job, Periodic) {
+func (ent *E2ENodeTest) generate() (Job, Periodic, TestGroup, error) {
 	log.Printf("generating e2eNode job: %s", ent.JobName)
 	if len(ent.fields) != 6 {
-		log.Fatalln("Expected 6 fields in job name", ent.JobName)
+		return Job{}, Periodic{}, TestGroup{}, fmt.Errorf("expected 6 fields in job name %q, got %d", ent.JobName, len(ent.fields))
 	}
 	image := ent.Image
 	K8SVersion := ent.K8SVersion
@@ -495,7 +1284,11 @@ func (ent *E2ENodeTest) generate() (Job, Periodic) {
 	args = append(args, getArgs(ent.JobName, testSuite.Args)...)
 
 	jobConfig := ent.getJobDefinition(args)
-	prowConfig := ent.getProwConfig(testSuite, K8SVersion)
+	prowConfig, err := ent.getProwConfig(testSuite, K8SVersion)
+	if err != nil {
+		return Job{}, Periodic{}, TestGroup{}, err
+	}
+	tgConfig := ent.getTestGridConfig()
 
 	nodeArgs := []string{}
 	jobArgs := []string{}
@@ -524,7 +1317,7 @@ func (ent *E2ENodeTest) generate() (Job, Periodic) {
 		tabName := fmt.Sprintf("%s-%s-%s", ent.fields[3], ent.fields[4], ent.fields[5])
 		prowConfig.Annotations["testgrid-tab-name"] = tabName
 	}
-	return jobConfig, prowConfig
+	return jobConfig, prowConfig, tgConfig, nil
 }
 
 type ConfigFile struct {
@@ -538,6 +1331,152 @@ type ConfigFile struct {
 	NodeK8SVersions map[string]NodeK8SVersion `yaml:"nodeK8sVersions"`
 	NodeImages      map[string]Image          `yaml:"nodeImages"`
 	NodeCommon      Common                    `yaml:"nodeCommon"`
+	// Matrix/UpgradeMatrix let a config synthesize many Jobs entries
+	// instead of hand-maintaining each one; see generateMatrix and
+	// generateUpgradeMatrix.
+	Matrix        []MatrixEntry        `yaml:"matrix"`
+	UpgradeMatrix []UpgradeMatrixEntry `yaml:"upgradeMatrix"`
+	// DashboardGroups bundles testgrid dashboards this config generates
+	// (via testgrid-dashboards annotations) under a named group, e.g. all
+	// of a release's blocking/informing dashboards under "sig-release-1.30".
+	DashboardGroups []DashboardGroupEntry `yaml:"dashboardGroups"`
+}
+
+// DashboardGroupEntry names a testgrid dashboard_group and the dashboard
+// names it bundles together. The named dashboards must themselves be
+// produced by some job's testgrid-dashboards annotation.
+type DashboardGroupEntry struct {
+	Name       string   `yaml:"name"`
+	Dashboards []string `yaml:"dashboards"`
+}
+
+// MatrixEntry expands into one Jobs entry per combination of
+// K8SVersions x Images x CloudProviders x TestSuites, skipping any
+// combination whose "<cloudProvider>-<image>-v1.<k8sVersion>-<testSuite>"
+// suffix is named in Exclude, or that isn't named in Include when Include
+// is non-empty. Job carries the fields (interval/cron/sigOwners/etc.)
+// applied to every job the entry generates.
+type MatrixEntry struct {
+	K8SVersions    []string `yaml:"k8sVersions"`
+	Images         []string `yaml:"images"`
+	CloudProviders []string `yaml:"cloudProviders"`
+	TestSuites     []string `yaml:"testSuites"`
+	Include        []string `yaml:"include"`
+	Exclude        []string `yaml:"exclude"`
+	Job            Job      `yaml:"job"`
+}
+
+// UpgradeMatrixEntry expands into one Jobs entry per combination of
+// CloudProviders x Images x TestSuites, each bootstrapped at From and
+// upgraded to To. UpgradeType selects which ginkgo.focus selector is used:
+// "control-plane", "node", or "both" (the default).
+type UpgradeMatrixEntry struct {
+	From           string   `yaml:"from"`
+	To             string   `yaml:"to"`
+	UpgradeType    string   `yaml:"upgradeType"`
+	Images         []string `yaml:"images"`
+	CloudProviders []string `yaml:"cloudProviders"`
+	TestSuites     []string `yaml:"testSuites"`
+	Job            Job      `yaml:"job"`
+}
+
+// generateMatrix expands every MatrixEntry into concrete Jobs entries,
+// named like the hand-written "ci-kubernetes-e2e-<cloudProvider>-<image>-
+// v1.<k8sVersion>-<testSuite>" jobs so they flow through forEachJob
+// unchanged.
+func generateMatrix(config ConfigFile) map[string]Job {
+	jobs := map[string]Job{}
+	for _, entry := range config.Matrix {
+		include := toSet(entry.Include)
+		exclude := toSet(entry.Exclude)
+		for _, cloudProvider := range entry.CloudProviders {
+			for _, image := range entry.Images {
+				for _, k8sVersion := range entry.K8SVersions {
+					for _, testSuite := range entry.TestSuites {
+						suffix := fmt.Sprintf("%s-%s-v1.%s-%s", cloudProvider, image, k8sVersion, testSuite)
+						if len(include) != 0 {
+							if _, ok := include[suffix]; !ok {
+								continue
+							}
+						}
+						if _, ok := exclude[suffix]; ok {
+							continue
+						}
+						jobName := fmt.Sprintf("ci-kubernetes-e2e-%s", suffix)
+						jobs[jobName] = entry.Job
+					}
+				}
+			}
+		}
+	}
+	return jobs
+}
+
+// generateUpgradeMatrix expands every UpgradeMatrixEntry into concrete
+// Jobs entries bootstrapped at From and upgraded to To, reusing the
+// existing arg-override flow to prepend the bootstrap/upgrade args and the
+// testgrid annotation flow (via Job.TestgridDashboardOverride) to tag the
+// job onto a sig-cluster-lifecycle-<from>-<to>-upgrade dashboard.
+func generateUpgradeMatrix(config ConfigFile) map[string]Job {
+	jobs := map[string]Job{}
+	for _, entry := range config.UpgradeMatrix {
+		job := entry.Job
+		job.Args = append(upgradeArgs(entry), job.Args...)
+		job.TestgridDashboardOverride = fmt.Sprintf("sig-cluster-lifecycle-%s-%s-upgrade", entry.From, entry.To)
+		for _, cloudProvider := range entry.CloudProviders {
+			for _, image := range entry.Images {
+				for _, testSuite := range entry.TestSuites {
+					jobName := fmt.Sprintf("ci-kubernetes-e2e-%s-%s-v1.%s-%s", cloudProvider, image, entry.From, testSuite)
+					jobs[jobName] = job
+				}
+			}
+		}
+	}
+	return jobs
+}
+
+// upgradeArgs builds the bootstrap-at-From, upgrade-to-To args an
+// UpgradeMatrixEntry prepends onto every job it generates.
+func upgradeArgs(entry UpgradeMatrixEntry) []string {
+	args := []string{
+		fmt.Sprintf("--extract=ci/latest-%s", entry.From),
+		fmt.Sprintf("--upgrade-target=v%s", entry.To),
+	}
+	switch entry.UpgradeType {
+	case "control-plane":
+		args = append(args, `--ginkgo.focus=\[Feature:ClusterUpgradeControlPlane\]`)
+	case "node":
+		args = append(args, `--ginkgo.focus=\[Feature:ClusterUpgradeNode\]`)
+	default:
+		args = append(args, `--ginkgo.focus=\[Feature:ClusterUpgrade\]`)
+	}
+	return args
+}
+
+// mergeGeneratedJobs folds the matrix/upgradeMatrix-generated jobs into
+// config.Jobs, without clobbering any hand-written job of the same name.
+func mergeGeneratedJobs(config *ConfigFile) {
+	if config.Jobs == nil {
+		config.Jobs = map[string]Job{}
+	}
+	for name, job := range generateMatrix(*config) {
+		if _, exists := config.Jobs[name]; !exists {
+			config.Jobs[name] = job
+		}
+	}
+	for name, job := range generateUpgradeMatrix(*config) {
+		if _, exists := config.Jobs[name]; !exists {
+			config.Jobs[name] = job
+		}
+	}
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
 }
 
 type E2ETest struct {
@@ -579,6 +1518,37 @@ type Job struct {
 	TestgridNumFailuresToAlert int      `yaml:"testgridNumFailuresToAlert"`
 	Args                       []string `yaml:"args"`
 	Resources                  Resources
+	// Optional marks a presubmit as not required to pass before merge.
+	Optional bool `yaml:"optional"`
+	// Branches/SkipBranches restrict a presubmit or postsubmit to the
+	// given set of branches, mirroring Prow's own job config fields.
+	Branches     []string `yaml:"branches"`
+	SkipBranches []string `yaml:"skipBranches"`
+	// AlertEmails/NumPassesToDisableAlert feed the testgrid dashboard
+	// tab's alert_options; ShortText is shown alongside the tab name.
+	AlertEmails             []string `yaml:"alertEmails"`
+	NumPassesToDisableAlert int      `yaml:"numPassesToDisableAlert"`
+	ShortText               string   `yaml:"shortText"`
+	// TestgridDashboardOverride replaces the computed testgrid-dashboards
+	// annotation wholesale. Used by generateUpgradeMatrix to pin upgrade
+	// jobs onto their own sig-cluster-lifecycle dashboard.
+	TestgridDashboardOverride string `yaml:"testgridDashboardOverride"`
+	// Runner selects the scenario runner: "scenario" (default, the
+	// kubernetes_e2e.py runner.sh wrapper) or "kubetest2".
+	Runner string `yaml:"runner"`
+	// Container/pod spec extensions, layered onto the generated Spec.
+	Env             []EnvVar          `yaml:"env"`
+	VolumeMounts    []VolumeMount     `yaml:"volumeMounts"`
+	Volumes         []Volume          `yaml:"volumes"`
+	NodeSelector    map[string]string `yaml:"nodeSelector"`
+	Tolerations     []Toleration      `yaml:"tolerations"`
+	ServiceAccount  string            `yaml:"serviceAccount"`
+	SecurityContext *SecurityContext  `yaml:"securityContext"`
+	// Prow-level job knobs, layered onto the generated Periodic/
+	// Presubmit/Postsubmit.
+	MaxConcurrency int        `yaml:"maxConcurrency"`
+	ExtraRefs      []ExtraRef `yaml:"extraRefs"`
+	PathAlias      string     `yaml:"pathAlias"`
 }
 
 type Common struct {
@@ -587,7 +1557,18 @@ type Common struct {
 }
 
 type CloudProvider struct {
-	Args []string
+	Args      []string
+	Kubetest2 Kubetest2Config `yaml:"kubetest2"`
+}
+
+// Kubetest2Config describes how to invoke kubetest2 for a CloudProvider
+// (the deployer) or TestSuite (the tester): `kubetest2 <deployer> <deployer
+// args> --up --down --test=<tester> -- <tester args>`.
+type Kubetest2Config struct {
+	Deployer     string   `yaml:"deployer"` // gce, kind, gke, ec2
+	Tester       string   `yaml:"tester"`   // ginkgo, exec
+	DeployerArgs []string `yaml:"deployerArgs"`
+	TesterArgs   []string `yaml:"testerArgs"`
 }
 
 type Image struct {
@@ -619,14 +1600,18 @@ type K8SVersion struct {
 }
 
 type TestSuite struct {
-	Args      []string  `yaml:"args"`
-	Resources Resources `yaml:"resources"`
-	Cluster   string    `yaml:"cluster"`
+	Args      []string        `yaml:"args"`
+	Resources Resources       `yaml:"resources"`
+	Cluster   string          `yaml:"cluster"`
+	Kubetest2 Kubetest2Config `yaml:"kubetest2"`
 }
 
 // Prow Config Generated File
 type ProwConfigFile struct {
 	Periodics []Periodic `yaml:"periodics"`
+	// Presubmits/Postsubmits are keyed by repo, as Prow requires.
+	Presubmits  map[string][]Presubmit  `yaml:"presubmits,omitempty"`
+	Postsubmits map[string][]Postsubmit `yaml:"postsubmits,omitempty"`
 }
 
 type Periodic struct {
@@ -640,6 +1625,62 @@ type Periodic struct {
 	Spec             Spec              `yaml:"spec"`
 	Cluster          string            `yaml:"cluster"`
 	Annotations      map[string]string `yaml:"annotations"`
+	ProwOptions      `yaml:",inline"`
+}
+
+// Presubmit is the PR-triggered counterpart of Periodic.
+type Presubmit struct {
+	Tags             []string          `yaml:"tags"`
+	Labels           map[string]string `yaml:"labels"`
+	Decorate         bool              `yaml:"decorate"`
+	DecorationConfig DecorationConfig  `yaml:"decoration_config"`
+	Name             string            `yaml:"name"`
+	Spec             Spec              `yaml:"spec"`
+	Cluster          string            `yaml:"cluster"`
+	Annotations      map[string]string `yaml:"annotations"`
+	AlwaysRun        bool              `yaml:"always_run"`
+	Optional         bool              `yaml:"optional"`
+	Trigger          string            `yaml:"trigger"`
+	RerunCommand     string            `yaml:"rerun_command"`
+	Context          string            `yaml:"context"`
+	Report           bool              `yaml:"report"`
+	Branches         []string          `yaml:"branches,omitempty"`
+	SkipBranches     []string          `yaml:"skip_branches,omitempty"`
+	ProwOptions      `yaml:",inline"`
+}
+
+// Postsubmit is the post-merge counterpart of Periodic.
+type Postsubmit struct {
+	Tags             []string          `yaml:"tags"`
+	Labels           map[string]string `yaml:"labels"`
+	Decorate         bool              `yaml:"decorate"`
+	DecorationConfig DecorationConfig  `yaml:"decoration_config"`
+	Name             string            `yaml:"name"`
+	Spec             Spec              `yaml:"spec"`
+	Cluster          string            `yaml:"cluster"`
+	Annotations      map[string]string `yaml:"annotations"`
+	Context          string            `yaml:"context"`
+	Report           bool              `yaml:"report"`
+	Branches         []string          `yaml:"branches,omitempty"`
+	SkipBranches     []string          `yaml:"skip_branches,omitempty"`
+	ProwOptions      `yaml:",inline"`
+}
+
+// ProwOptions holds the Prow-level job knobs shared by Periodic,
+// Presubmit, and Postsubmit.
+type ProwOptions struct {
+	MaxConcurrency int        `yaml:"max_concurrency,omitempty"`
+	ExtraRefs      []ExtraRef `yaml:"extra_refs,omitempty"`
+	PathAlias      string     `yaml:"path_alias,omitempty"`
+}
+
+// ExtraRef is an additional repo Prow checks out alongside the job's own
+// repo before running the test container.
+type ExtraRef struct {
+	Org     string `yaml:"org"`
+	Repo    string `yaml:"repo"`
+	BaseRef string `yaml:"baseRef"`
+	WorkDir bool   `yaml:"workDir"`
 }
 
 type DecorationConfig struct {
@@ -647,20 +1688,72 @@ type DecorationConfig struct {
 }
 
 type Spec struct {
-	Containers []Container `yaml:"containers"`
+	Containers     []Container       `yaml:"containers"`
+	NodeSelector   map[string]string `yaml:"nodeSelector,omitempty"`
+	Tolerations    []Toleration      `yaml:"tolerations,omitempty"`
+	Volumes        []Volume          `yaml:"volumes,omitempty"`
+	ServiceAccount string            `yaml:"serviceAccountName,omitempty"`
 }
 
 type Container struct {
-	Command   []string  `yaml:"command"`
-	Args      []string  `yaml:"args"`
-	Env       string    `yaml:"env"`
-	Image     string    `yaml:"image"`
-	Resources Resources `yaml:"resources"`
+	Command         []string         `yaml:"command"`
+	Args            []string         `yaml:"args"`
+	Env             []EnvVar         `yaml:"env"`
+	Image           string           `yaml:"image"`
+	Resources       Resources        `yaml:"resources"`
+	VolumeMounts    []VolumeMount    `yaml:"volumeMounts,omitempty"`
+	SecurityContext *SecurityContext `yaml:"securityContext,omitempty"`
+}
+
+type EnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type VolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly"`
+}
+
+// Volume is a pod volume listed in a job's config. Only the emptyDir source
+// is supported; a Volume with EmptyDir unset has no volume source.
+type Volume struct {
+	Name     string `yaml:"name"`
+	EmptyDir bool   `yaml:"emptyDir"`
+}
+
+type Toleration struct {
+	Key      string `yaml:"key"`
+	Operator string `yaml:"operator"`
+	Value    string `yaml:"value"`
+	Effect   string `yaml:"effect"`
+}
+
+type SecurityContext struct {
+	RunAsUser    *int64 `yaml:"runAsUser,omitempty"`
+	RunAsNonRoot *bool  `yaml:"runAsNonRoot,omitempty"`
 }
 
 // Testgrid
 type TestgridConfig struct {
-	TestGroups []TestGroup `json:"test_groups"`
+	TestGroups      []TestGroup      `json:"test_groups"`
+	Dashboards      []Dashboard      `json:"dashboards"`
+	DashboardGroups []DashboardGroup `json:"dashboard_groups,omitempty"`
+}
+
+// MarshalYAML makes yaml.v3 (used by writeConfigToFile) emit the key names
+// given by the json tags of TestgridConfig and the types it contains.
+func (c TestgridConfig) MarshalYAML() (interface{}, error) {
+	data, err := kyaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
 type TestGroup struct {
@@ -670,9 +1763,36 @@ type TestGroup struct {
 }
 
 func (tg *TestGroup) isEmpty() bool {
-	return tg.Name != "" || tg.GCSPrefix != "" || len(tg.ColumnHeader) != 0
+	return tg.Name == "" && tg.GCSPrefix == "" && len(tg.ColumnHeader) == 0
 }
 
 type ConfigurationValue struct {
 	ConfigurationValue string `json:"configuration_value"`
 }
+
+// Dashboard groups one or more DashboardTab entries under a testgrid
+// dashboard name, e.g. "sig-release-1.30-blocking".
+type Dashboard struct {
+	Name         string         `json:"name"`
+	DashboardTab []DashboardTab `json:"dashboard_tab"`
+}
+
+// DashboardTab links a dashboard to the TestGroup it displays.
+type DashboardTab struct {
+	Name          string        `json:"name"`
+	TestGroupName string        `json:"test_group_name"`
+	ShortText     string        `json:"short_text,omitempty"`
+	AlertOptions  *AlertOptions `json:"alert_options,omitempty"`
+}
+
+// AlertOptions controls when testgrid should page for a flaky/failing tab.
+type AlertOptions struct {
+	AlertMailToAddresses    string `json:"alert_mail_to_addresses,omitempty"`
+	NumPassesToDisableAlert int    `json:"num_passes_to_disable_alert,omitempty"`
+}
+
+// DashboardGroup bundles related dashboards together in the testgrid UI.
+type DashboardGroup struct {
+	Name           string   `json:"name"`
+	DashboardNames []string `json:"dashboard_names"`
+}
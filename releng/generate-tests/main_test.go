@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestBuildRunnerCommandGoldenFiles locks down the exact container command,
+// args, and decoration timeout each scenario runner produces for the same
+// small config. This guards the --timeout/--test-timeout translation in
+// buildRunnerCommand/kubetest2Args/timeoutDecoration: a regression there
+// (e.g. scanning testSuite.Args for the wrong prefix) silently zeroes out
+// the decoration timeout for kubetest2 jobs without failing any other check.
+func TestBuildRunnerCommandGoldenFiles(t *testing.T) {
+	config := ConfigFile{
+		Common: Common{Args: []string{"--foo=bar"}},
+		CloudProviders: map[string]CloudProvider{
+			"gce": {
+				Args: []string{"--provider=gce"},
+				Kubetest2: Kubetest2Config{
+					Deployer:     "gce",
+					DeployerArgs: []string{"--legacy-mode"},
+				},
+			},
+		},
+		Images: map[string]Image{
+			"default": {},
+		},
+		K8SVersions: map[string]K8SVersion{
+			"30": {Version: "1.30"},
+		},
+		TestSuites: map[string]TestSuite{
+			"default": {
+				Args:      []string{"--timeout=60m", "--ginkgo.focus=Conformance"},
+				Kubetest2: Kubetest2Config{Tester: "ginkgo"},
+			},
+		},
+	}
+	const jobName = "ci-kubernetes-e2e-gce-default-v1.30-default"
+
+	for _, tc := range []struct {
+		runner     string
+		goldenFile string
+	}{
+		{"scenario", "testdata/runner_scenario.golden"},
+		{"kubetest2", "testdata/runner_kubetest2.golden"},
+	} {
+		t.Run(tc.runner, func(t *testing.T) {
+			job := Job{Scenario: "kubernetes_e2e", Interval: "6h", Runner: tc.runner}
+			generated, _, err := forEachJob("", jobName, job, config)
+			if err != nil {
+				t.Fatalf("forEachJob: %v", err)
+			}
+			container := generated.Periodic.Spec.Containers[0]
+			got := fmt.Sprintf(
+				"command: %s\nargs: %s\ntimeout: %s\n",
+				strings.Join(container.Command, " "),
+				strings.Join(container.Args, " "),
+				generated.Periodic.DecorationConfig.Timeout,
+			)
+			want, err := os.ReadFile(filepath.FromSlash(tc.goldenFile))
+			if err != nil {
+				t.Fatalf("reading golden file: %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("buildRunnerCommand(runner=%s) output mismatch\ngot:\n%s\nwant:\n%s", tc.runner, got, want)
+			}
+		})
+	}
+}
+
+// TestForEachJobPresubmitPostsubmit checks that e2epr/e2epostmerge job
+// names route through generatePresubmit/generatePostsubmit (not just
+// Periodics) and come out with the trigger/rerun/report fields those job
+// kinds need.
+func TestForEachJobPresubmitPostsubmit(t *testing.T) {
+	config := ConfigFile{
+		CloudProviders: map[string]CloudProvider{"gce": {}},
+		Images:         map[string]Image{"default": {}},
+		K8SVersions:    map[string]K8SVersion{"30": {Version: "1.30"}},
+		TestSuites:     map[string]TestSuite{"default": {Args: []string{"--timeout=60m"}}},
+	}
+	job := Job{Scenario: "kubernetes_e2e"}
+
+	t.Run("e2epr", func(t *testing.T) {
+		const jobName = "ci-kubernetes-e2epr-gce-default-v1.30-default"
+		generated, _, err := forEachJob("", jobName, job, config)
+		if err != nil {
+			t.Fatalf("forEachJob: %v", err)
+		}
+		if generated.Kind != PresubmitJob {
+			t.Fatalf("Kind = %v, want PresubmitJob", generated.Kind)
+		}
+		if !generated.Presubmit.AlwaysRun {
+			t.Errorf("AlwaysRun = false, want true for a non-optional job")
+		}
+		if generated.Presubmit.RerunCommand != "/test "+jobName {
+			t.Errorf("RerunCommand = %q, want %q", generated.Presubmit.RerunCommand, "/test "+jobName)
+		}
+	})
+
+	t.Run("e2epostmerge", func(t *testing.T) {
+		const jobName = "ci-kubernetes-e2epostmerge-gce-default-v1.30-default"
+		generated, _, err := forEachJob("", jobName, job, config)
+		if err != nil {
+			t.Fatalf("forEachJob: %v", err)
+		}
+		if generated.Kind != PostsubmitJob {
+			t.Fatalf("Kind = %v, want PostsubmitJob", generated.Kind)
+		}
+		if !generated.Postsubmit.Report {
+			t.Errorf("Report = false, want true")
+		}
+		if generated.Postsubmit.Context != jobName {
+			t.Errorf("Context = %q, want %q", generated.Postsubmit.Context, jobName)
+		}
+	})
+}
+
+// TestTestgridWiring guards the main()-loop contract between forEachJob,
+// TestGroup.isEmpty, and appendDashboardTabs: every real job (e2e and
+// e2enode alike) must produce a non-empty TestGroup so it actually makes it
+// into the written testgrid config, and its testgrid-dashboards annotation
+// must turn into a Dashboard/DashboardTab entry.
+func TestTestgridWiring(t *testing.T) {
+	config := ConfigFile{
+		// e2enode jobs only carry a testgrid-dashboards annotation when
+		// their image sets a testgrid_prefix.
+		Images:      map[string]Image{"default": {TestgridPrefix: "sig-node"}},
+		K8SVersions: map[string]K8SVersion{"30": {Version: "1.30"}},
+		TestSuites:  map[string]TestSuite{"default": {Args: []string{"--timeout=60m"}}},
+		NodeK8SVersions: map[string]NodeK8SVersion{
+			"30": {},
+		},
+		CloudProviders: map[string]CloudProvider{"gce": {}},
+	}
+
+	cases := []struct {
+		name    string
+		jobName string
+		job     Job
+	}{
+		{"e2e", "ci-kubernetes-e2e-gce-default-v1.30-default", Job{Scenario: "kubernetes_e2e", Interval: "6h"}},
+		{"e2enode", "ci-kubernetes-e2enode-default-v1.30-default", Job{Interval: "6h"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			generated, testgrid, err := forEachJob("", tc.jobName, tc.job, config)
+			if err != nil {
+				t.Fatalf("forEachJob: %v", err)
+			}
+			if testgrid.isEmpty() {
+				t.Fatalf("TestGroup for %s came back empty, it should describe a real test group", tc.jobName)
+			}
+			testgridConfig := TestgridConfig{}
+			dashboardIndex := map[string]int{}
+			appendDashboardTabs(&testgridConfig, dashboardIndex, generated.annotations(), testgrid.Name, config.Jobs[tc.jobName])
+			if len(testgridConfig.Dashboards) == 0 {
+				t.Fatalf("appendDashboardTabs recorded no dashboards for %s", tc.jobName)
+			}
+			if got := testgridConfig.Dashboards[0].DashboardTab[0].TestGroupName; got != testgrid.Name {
+				t.Errorf("DashboardTab.TestGroupName = %q, want %q", got, testgrid.Name)
+			}
+		})
+	}
+}
+
+// TestDashboardGroupsWiring locks down that a ConfigFile's DashboardGroups
+// section turns into TestgridConfig.DashboardGroups entries, one per
+// config entry, with the same name/dashboards it was given.
+func TestDashboardGroupsWiring(t *testing.T) {
+	entries := []DashboardGroupEntry{
+		{Name: "sig-release-1.30", Dashboards: []string{"sig-release-1.30-blocking", "sig-release-1.30-informing"}},
+	}
+	groups := buildDashboardGroups(entries)
+	if len(groups) != 1 {
+		t.Fatalf("got %d DashboardGroups, want 1", len(groups))
+	}
+	if groups[0].Name != entries[0].Name || len(groups[0].DashboardNames) != 2 {
+		t.Errorf("DashboardGroups[0] = %+v, want Name=%q with 2 dashboards", groups[0], entries[0].Name)
+	}
+}
+
+// TestGenerateMatrix checks that a MatrixEntry expands into the expected
+// "ci-kubernetes-e2e-<cloudProvider>-<image>-v1.<k8sVersion>-<testSuite>"
+// job names, and that include/exclude filter the combination suffix
+// ("<cloudProvider>-<image>-v1.<k8sVersion>-<testSuite>") rather than the
+// full job name.
+func TestGenerateMatrix(t *testing.T) {
+	config := ConfigFile{
+		Matrix: []MatrixEntry{
+			{
+				CloudProviders: []string{"gce", "aws"},
+				Images:         []string{"default"},
+				K8SVersions:    []string{"30"},
+				TestSuites:     []string{"default"},
+				Exclude:        []string{"aws-default-v1.30-default"},
+				Job:            Job{Interval: "6h"},
+			},
+		},
+	}
+	jobs := generateMatrix(config)
+	if _, ok := jobs["ci-kubernetes-e2e-gce-default-v1.30-default"]; !ok {
+		t.Errorf("expected gce job to be generated, got %v", jobs)
+	}
+	if _, ok := jobs["ci-kubernetes-e2e-aws-default-v1.30-default"]; ok {
+		t.Errorf("expected aws job to be excluded, got %v", jobs)
+	}
+	if len(jobs) != 1 {
+		t.Errorf("got %d jobs, want 1: %v", len(jobs), jobs)
+	}
+}
+
+// TestGenerateUpgradeMatrix checks that an UpgradeMatrixEntry expands into
+// a job named after its From version, with the bootstrap/upgrade args
+// prepended and the upgrade testgrid dashboard override set.
+func TestGenerateUpgradeMatrix(t *testing.T) {
+	config := ConfigFile{
+		UpgradeMatrix: []UpgradeMatrixEntry{
+			{
+				From:           "29",
+				To:             "30",
+				UpgradeType:    "node",
+				CloudProviders: []string{"gce"},
+				Images:         []string{"default"},
+				TestSuites:     []string{"default"},
+				Job:            Job{Args: []string{"--extra=1"}},
+			},
+		},
+	}
+	jobs := generateUpgradeMatrix(config)
+	job, ok := jobs["ci-kubernetes-e2e-gce-default-v1.29-default"]
+	if !ok {
+		t.Fatalf("expected upgrade job bootstrapped at v1.29, got %v", jobs)
+	}
+	wantArgs := []string{"--extract=ci/latest-29", "--upgrade-target=v30", `--ginkgo.focus=\[Feature:ClusterUpgradeNode\]`, "--extra=1"}
+	if !slices.Equal(job.Args, wantArgs) {
+		t.Errorf("Args = %v, want %v", job.Args, wantArgs)
+	}
+	const wantDashboard = "sig-cluster-lifecycle-29-30-upgrade"
+	if job.TestgridDashboardOverride != wantDashboard {
+		t.Errorf("TestgridDashboardOverride = %q, want %q", job.TestgridDashboardOverride, wantDashboard)
+	}
+}
+
+// TestMergeGeneratedJobs checks that matrix/upgradeMatrix-generated jobs
+// are folded into config.Jobs without clobbering a hand-written job of the
+// same name.
+func TestMergeGeneratedJobs(t *testing.T) {
+	const jobName = "ci-kubernetes-e2e-gce-default-v1.30-default"
+	handWritten := Job{Interval: "12h"}
+	config := ConfigFile{
+		Jobs: map[string]Job{jobName: handWritten},
+		Matrix: []MatrixEntry{
+			{
+				CloudProviders: []string{"gce"},
+				Images:         []string{"default"},
+				K8SVersions:    []string{"30"},
+				TestSuites:     []string{"default"},
+				Job:            Job{Interval: "6h"},
+			},
+		},
+	}
+	mergeGeneratedJobs(&config)
+	if got := config.Jobs[jobName]; got.Interval != handWritten.Interval {
+		t.Errorf("hand-written job was clobbered: got Interval %q, want %q", got.Interval, handWritten.Interval)
+	}
+}
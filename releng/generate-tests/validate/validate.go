@@ -0,0 +1,156 @@
+// Package validate collects generate-tests config problems with
+// file:line:column context instead of failing fast on the first one, so a
+// single run can report everything wrong with a config in one pass.
+package validate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Error is a single validation failure, optionally anchored to a location
+// in the source YAML file.
+type Error struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// Errors is a collection of Error, satisfying the error interface with one
+// line per Error. Callers check len(errs) for success: a nil or empty
+// Errors stored in an error value is still a non-nil error.
+type Errors []*Error
+
+func (errs Errors) Error() string {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Collector accumulates Errors across an entire config validation pass.
+type Collector struct {
+	file   string
+	Errors Errors
+}
+
+func NewCollector(file string) *Collector {
+	return &Collector{file: file}
+}
+
+// Addf records a validation failure anchored to node, or to the file as a
+// whole if node is nil.
+func (c *Collector) Addf(node *yaml.Node, format string, args ...interface{}) {
+	err := &Error{File: c.file, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		err.Line = node.Line
+		err.Column = node.Column
+	}
+	c.Errors = append(c.Errors, err)
+}
+
+func (c *Collector) HasErrors() bool {
+	return len(c.Errors) > 0
+}
+
+// Keys is the set of config keys a job name's tokens must resolve to,
+// gathered from the top-level config sections.
+type Keys struct {
+	CloudProviders  map[string]struct{}
+	Images          map[string]struct{}
+	K8SVersions     map[string]struct{}
+	NodeK8SVersions map[string]struct{}
+	TestSuites      map[string]struct{}
+}
+
+// JobNameTokenCount returns the number of "-" separated tokens a job name
+// is expected to have for the given jobType (the jobName's 3rd token).
+func JobNameTokenCount(jobType string) (int, bool) {
+	switch jobType {
+	case "e2e", "e2epr", "e2epostmerge":
+		return 7, true
+	case "e2enode":
+		return 6, true
+	default:
+		return 0, false
+	}
+}
+
+// CheckJobNameTokens verifies jobName has the expected token count for its
+// jobType and that every token that should reference a config key
+// (cloudProvider, image, k8sVersion, testSuite) actually does.
+func CheckJobNameTokens(c *Collector, node *yaml.Node, jobName string, keys Keys) {
+	fields := strings.Split(jobName, "-")
+	if len(fields) < 3 {
+		c.Addf(node, "job %q: expected at least 3 fields in job name", jobName)
+		return
+	}
+	jobType := fields[2]
+	want, known := JobNameTokenCount(jobType)
+	if !known {
+		c.Addf(node, "job %q: unexpected job type %q", jobName, jobType)
+		return
+	}
+	if len(fields) != want {
+		c.Addf(node, "job %q: expected %d fields for job type %q, got %d", jobName, want, jobType, len(fields))
+		return
+	}
+	switch jobType {
+	case "e2e", "e2epr", "e2epostmerge":
+		checkKey(c, node, jobName, "cloudProvider", fields[3], keys.CloudProviders)
+		checkKey(c, node, jobName, "image", fields[4], keys.Images)
+		checkKey(c, node, jobName, "k8sVersion", strings.TrimPrefix(fields[5], "v1."), keys.K8SVersions)
+		checkKey(c, node, jobName, "testSuite", fields[6], keys.TestSuites)
+	case "e2enode":
+		checkKey(c, node, jobName, "image", fields[3], keys.Images)
+		checkKey(c, node, jobName, "nodeK8sVersion", strings.TrimPrefix(fields[4], "v1."), keys.NodeK8SVersions)
+		checkKey(c, node, jobName, "testSuite", fields[5], keys.TestSuites)
+	}
+}
+
+func checkKey(c *Collector, node *yaml.Node, jobName, kind, token string, keys map[string]struct{}) {
+	if _, ok := keys[token]; !ok {
+		c.Addf(node, "job %q: %s token %q does not match any configured %s", jobName, kind, token, kind)
+	}
+}
+
+// CheckArg validates the well-formedness of a single job/test-suite arg: it
+// must look like --flag or --flag=value, and --timeout must be an integer
+// number of minutes.
+func CheckArg(c *Collector, node *yaml.Node, arg string) {
+	trimmed := strings.TrimSpace(arg)
+	if !strings.HasPrefix(trimmed, "--") {
+		c.Addf(node, "arg %q: expected a flag starting with --", arg)
+		return
+	}
+	name, value, hasValue := strings.Cut(strings.TrimPrefix(trimmed, "--"), "=")
+	if name == "" {
+		c.Addf(node, "arg %q: missing flag name", arg)
+		return
+	}
+	if !hasValue {
+		return
+	}
+	if name != "timeout" {
+		return
+	}
+	if !strings.HasSuffix(value, "m") {
+		c.Addf(node, "arg %q: --timeout must be of the form <int>m", arg)
+		return
+	}
+	if _, err := strconv.Atoi(strings.TrimSuffix(value, "m")); err != nil {
+		c.Addf(node, "arg %q: --timeout value %q is not an integer number of minutes", arg, value)
+	}
+}
@@ -0,0 +1,60 @@
+package validate
+
+import "testing"
+
+func TestCheckArg(t *testing.T) {
+	cases := []struct {
+		name    string
+		arg     string
+		wantErr bool
+	}{
+		{"flag with value", "--ginkgo.focus=Conformance", false},
+		{"no-value flag", "--report-dir", false},
+		{"missing leading dashes", "ginkgo.focus=Conformance", true},
+		{"empty flag name", "--=value", true},
+		{"valid timeout", "--timeout=60m", false},
+		{"timeout missing m suffix", "--timeout=60", true},
+		{"timeout not an integer", "--timeout=abcm", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewCollector("test.yaml")
+			CheckArg(c, nil, tc.arg)
+			if got := c.HasErrors(); got != tc.wantErr {
+				t.Errorf("CheckArg(%q): HasErrors() = %v, want %v (errors: %v)", tc.arg, got, tc.wantErr, c.Errors)
+			}
+		})
+	}
+}
+
+func TestCheckJobNameTokens(t *testing.T) {
+	keys := Keys{
+		CloudProviders:  map[string]struct{}{"gce": {}},
+		Images:          map[string]struct{}{"default": {}},
+		K8SVersions:     map[string]struct{}{"30": {}},
+		NodeK8SVersions: map[string]struct{}{"30": {}},
+		TestSuites:      map[string]struct{}{"default": {}},
+	}
+	cases := []struct {
+		name    string
+		jobName string
+		wantErr bool
+	}{
+		{"valid e2e", "ci-kubernetes-e2e-gce-default-v1.30-default", false},
+		{"valid e2enode", "ci-kubernetes-e2enode-default-v1.30-default", false},
+		{"too few fields", "ci-kubernetes-e2e", true},
+		{"unknown job type", "ci-kubernetes-bogus-gce-default-v1.30-default", true},
+		{"wrong field count for e2e", "ci-kubernetes-e2e-gce-default-v1.30-default-extra", true},
+		{"e2e cloudProvider not configured", "ci-kubernetes-e2e-aws-default-v1.30-default", true},
+		{"e2enode nodeK8sVersion not configured", "ci-kubernetes-e2enode-default-v1.31-default", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewCollector("test.yaml")
+			CheckJobNameTokens(c, nil, tc.jobName, keys)
+			if got := c.HasErrors(); got != tc.wantErr {
+				t.Errorf("CheckJobNameTokens(%q): HasErrors() = %v, want %v (errors: %v)", tc.jobName, got, tc.wantErr, c.Errors)
+			}
+		})
+	}
+}